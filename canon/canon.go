@@ -0,0 +1,353 @@
+// Package canon implements RFC 8785 JSON Canonicalization (JCS) and
+// JSON-Schema-driven leaf-value coercion, shared by post_hcs.go's `batch`
+// subcommand and verify.go so a recordHash computed by one matches the
+// other byte-for-byte. It's a real importable package (rather than being
+// duplicated into both standalone programs) specifically so its golden
+// test vectors can run without either program's main() in scope.
+package canon
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// CanonicalMarshal implements RFC 8785 JCS: object keys are sorted by
+// UTF-16 code-unit order, numbers render via the ES6 Number::toString
+// shortest round-trip form, and strings escape only the mandatory set. It
+// errors on NaN/±Inf and on any type JSON can't represent, rather than
+// silently falling back to encoding/json's (non-canonical) output.
+func CanonicalMarshal(v interface{}) ([]byte, error) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return utf16Less(keys[i], keys[j]) })
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, k := range keys {
+			b.Write(jcsMarshalString(k))
+			b.WriteByte(':')
+			vb, err := CanonicalMarshal(x[k])
+			if err != nil {
+				return nil, err
+			}
+			b.Write(vb)
+			if i < len(keys)-1 {
+				b.WriteByte(',')
+			}
+		}
+		b.WriteByte('}')
+		return []byte(b.String()), nil
+
+	case []interface{}:
+		var b strings.Builder
+		b.WriteByte('[')
+		for i := range x {
+			vb, err := CanonicalMarshal(x[i])
+			if err != nil {
+				return nil, err
+			}
+			b.Write(vb)
+			if i < len(x)-1 {
+				b.WriteByte(',')
+			}
+		}
+		b.WriteByte(']')
+		return []byte(b.String()), nil
+
+	case string:
+		return jcsMarshalString(x), nil
+
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("canonicalize number %q: %w", x.String(), err)
+		}
+		return jcsMarshalNumber(f)
+
+	case int64:
+		return []byte(strconv.FormatInt(x, 10)), nil
+
+	case float64:
+		return jcsMarshalNumber(x)
+
+	case bool:
+		if x {
+			return []byte("true"), nil
+		}
+		return []byte("false"), nil
+
+	case nil:
+		return []byte("null"), nil
+
+	default:
+		return nil, fmt.Errorf("canonicalize: unsupported type %T", v)
+	}
+}
+
+// utf16Less reports whether a sorts before b under a lexicographic
+// comparison of their UTF-16 code units, as RFC 8785 requires for object
+// key ordering.
+func utf16Less(a, b string) bool {
+	au := utf16.Encode([]rune(a))
+	bu := utf16.Encode([]rune(b))
+	for i := 0; i < len(au) && i < len(bu); i++ {
+		if au[i] != bu[i] {
+			return au[i] < bu[i]
+		}
+	}
+	return len(au) < len(bu)
+}
+
+// jcsMarshalString renders s as a JSON string, escaping only the mandatory
+// set (quote, backslash, and C0 control characters) per RFC 8785 — notably
+// it does NOT escape '/', non-ASCII runes, or U+2028/U+2029 the way Go's
+// encoding/json does by default.
+func jcsMarshalString(s string) []byte {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return []byte(b.String())
+}
+
+// jcsMarshalNumber renders f using the ES6 Number::toString shortest
+// round-trip form RFC 8785 mandates: no exponent for "ordinary" magnitudes,
+// a bare "0" for zero (collapsing -0), and NaN/±Inf are rejected since JSON
+// has no representation for them.
+func jcsMarshalNumber(f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("cannot canonicalize non-finite number %v", f)
+	}
+	if f == 0 {
+		return []byte("0"), nil
+	}
+
+	abs := math.Abs(f)
+	neg := math.Signbit(f)
+
+	var s string
+	if abs >= 1e21 || abs < 1e-6 {
+		s = normalizeJCSExponent(strconv.FormatFloat(abs, 'e', -1, 64))
+	} else {
+		s = strconv.FormatFloat(abs, 'f', -1, 64)
+	}
+	if neg {
+		s = "-" + s
+	}
+	return []byte(s), nil
+}
+
+// normalizeJCSExponent strips Go's zero-padded exponent (e.g. "1e+05") down
+// to the bare form ES6 Number::toString produces ("1e+5").
+func normalizeJCSExponent(s string) string {
+	idx := strings.IndexByte(s, 'e')
+	if idx < 0 {
+		return s
+	}
+	mantissa, exp := s[:idx], s[idx+1:]
+	sign := "+"
+	if len(exp) > 0 && (exp[0] == '+' || exp[0] == '-') {
+		if exp[0] == '-' {
+			sign = "-"
+		}
+		exp = exp[1:]
+	}
+	exp = strings.TrimLeft(exp, "0")
+	if exp == "" {
+		exp = "0"
+	}
+	return mantissa + "e" + sign + exp
+}
+
+// JSONSchema is the minimal subset of JSON Schema needed to steer
+// leaf-value typing: object/array recursion via properties/items, plus the
+// scalar types integer, number, string, and boolean.
+type JSONSchema struct {
+	Type       string                 `json:"type"`
+	Properties map[string]*JSONSchema `json:"properties"`
+	Items      *JSONSchema            `json:"items"`
+}
+
+// LoadSchema reads and parses a JSONSchema from path.
+func LoadSchema(path string) (*JSONSchema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s JSONSchema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CoerceToSchema walks v alongside schema, converting leaf values to their
+// declared JSON Schema type before canonicalization, so e.g. one exporter's
+// quoted "1" and another's bare 1 hash identically once both are known to be
+// integers.
+func CoerceToSchema(v interface{}, schema *JSONSchema) (interface{}, error) {
+	if schema == nil {
+		return v, nil
+	}
+	switch schema.Type {
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v, nil
+		}
+		out := make(map[string]interface{}, len(m))
+		for k, vv := range m {
+			cv, err := CoerceToSchema(vv, schema.Properties[k])
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", k, err)
+			}
+			out[k] = cv
+		}
+		return out, nil
+
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return v, nil
+		}
+		out := make([]interface{}, len(arr))
+		for i, vv := range arr {
+			cv, err := CoerceToSchema(vv, schema.Items)
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			out[i] = cv
+		}
+		return out, nil
+
+	case "integer":
+		return scalarToInt64(v)
+
+	case "number":
+		return scalarToFloat(v)
+
+	case "string":
+		return scalarToString(v), nil
+
+	case "boolean":
+		return scalarToBool(v)
+
+	default:
+		return v, nil
+	}
+}
+
+// scalarToInt64 coerces a decoded JSON leaf to int64. Integer literals are
+// parsed without routing through float64, so integers beyond 2^53 (e.g.
+// SurveyCTO's 64-bit submission IDs) don't lose precision; a json.Number
+// with a fraction or exponent (e.g. 1.0) is accepted only if its value is
+// integral.
+func scalarToInt64(v interface{}) (int64, error) {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := x.Float64()
+		if err != nil {
+			return 0, err
+		}
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("cannot coerce %s to an integer", x.String())
+		}
+		return int64(f), nil
+	case float64:
+		return int64(x), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(x), 10, 64)
+	case bool:
+		if x {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("cannot coerce %T to an integer", v)
+	}
+}
+
+// scalarToFloat coerces a decoded JSON leaf (json.Number, float64, bool, or
+// a numeric string) to float64.
+func scalarToFloat(v interface{}) (float64, error) {
+	switch x := v.(type) {
+	case json.Number:
+		return x.Float64()
+	case float64:
+		return x, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(x), 64)
+	case bool:
+		if x {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("cannot coerce %T to a number", v)
+	}
+}
+
+func scalarToString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+func scalarToBool(v interface{}) (bool, error) {
+	switch x := v.(type) {
+	case bool:
+		return x, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(x))
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return false, err
+		}
+		return f != 0, nil
+	default:
+		return false, fmt.Errorf("cannot coerce %T to a boolean", v)
+	}
+}
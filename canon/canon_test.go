@@ -0,0 +1,113 @@
+package canon
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestCanonicalMarshalGoldenVectors pins the exact byte output of
+// CanonicalMarshal for cases RFC 8785 singles out as easy to get wrong, so
+// downstream verifiers implemented in other languages can diff their own
+// output against these vectors instead of trusting prose alone.
+func TestCanonicalMarshalGoldenVectors(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{
+			name: "nested object",
+			in: map[string]interface{}{
+				"b": map[string]interface{}{"y": json.Number("2"), "x": json.Number("1")},
+				"a": []interface{}{json.Number("1"), json.Number("2"), json.Number("3")},
+			},
+			want: `{"a":[1,2,3],"b":{"x":1,"y":2}}`,
+		},
+		{
+			// RFC 8785 appendix example: a key whose first codepoint is a
+			// surrogate pair (U+10000) sorts BEFORE a BMP key (U+E000) under
+			// UTF-16 code-unit order, even though U+10000 > U+E000 as a
+			// Unicode codepoint — the reason utf16Less exists instead of a
+			// plain string/rune comparison.
+			name: "utf-16 surrogate pair sorts before higher BMP codepoint",
+			in: map[string]interface{}{
+				string(rune(0x10000)): json.Number("1"),
+				string(rune(0xE000)):  json.Number("2"),
+			},
+			want: `{"` + string(rune(0x10000)) + `":1,"` + string(rune(0xE000)) + `":2}`,
+		},
+		{
+			name: "-0 collapses to 0",
+			in:   map[string]interface{}{"v": float64(0) * -1},
+			want: `{"v":0}`,
+		},
+		{
+			name: "large magnitude uses exponential form at the 1e21 threshold",
+			in:   map[string]interface{}{"v": 1e21},
+			want: `{"v":1e+21}`,
+		},
+		{
+			name: "just under the 1e21 threshold stays fixed-point",
+			in:   map[string]interface{}{"v": 999999999999999900000.0},
+			want: `{"v":999999999999999900000}`,
+		},
+		{
+			name: "small magnitude stays fixed-point at the 1e-6 threshold",
+			in:   map[string]interface{}{"v": 0.000001},
+			want: `{"v":0.000001}`,
+		},
+		{
+			name: "just under the 1e-6 threshold uses exponential form",
+			in:   map[string]interface{}{"v": 0.0000001},
+			want: `{"v":1e-7}`,
+		},
+		{
+			name: "negative number keeps its sign across both forms",
+			in:   map[string]interface{}{"v": -1e21},
+			want: `{"v":-1e+21}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := CanonicalMarshal(c.in)
+			if err != nil {
+				t.Fatalf("CanonicalMarshal(%v) returned error: %v", c.in, err)
+			}
+			if string(got) != c.want {
+				t.Fatalf("CanonicalMarshal(%v) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+// TestUTF16LessOrdersBySurrogateCodeUnit exercises utf16Less directly for
+// the case CanonicalMarshal's key sort depends on: a supplementary-plane
+// codepoint (encoded as a UTF-16 surrogate pair) sorting before a BMP
+// codepoint that is smaller as a codepoint but whose single code unit is
+// numerically larger than the surrogate pair's leading (high) unit.
+func TestUTF16LessOrdersBySurrogateCodeUnit(t *testing.T) {
+	supplementary := string(rune(0x10000))
+	bmp := string(rune(0xE000))
+
+	if !utf16Less(supplementary, bmp) {
+		t.Fatalf("utf16Less(%q, %q) = false, want true (U+10000's surrogate pair starts with 0xD800, below 0xE000)", supplementary, bmp)
+	}
+	if utf16Less(bmp, supplementary) {
+		t.Fatalf("utf16Less(%q, %q) = true, want false", bmp, supplementary)
+	}
+}
+
+// TestScalarToInt64PreservesPrecision guards against the float64
+// intermediate CoerceToSchema's integer case used to route through, which
+// silently truncated integers beyond 2^53.
+func TestScalarToInt64PreservesPrecision(t *testing.T) {
+	in := json.Number("9007199254740993") // 2^53 + 1
+	got, err := scalarToInt64(in)
+	if err != nil {
+		t.Fatalf("scalarToInt64(%v) returned error: %v", in, err)
+	}
+	if want := int64(9007199254740993); got != want {
+		t.Fatalf("scalarToInt64(%v) = %d, want %d", in, got, want)
+	}
+}
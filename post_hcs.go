@@ -5,17 +5,101 @@ package main
 
 import (
 	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
-	hedera "github.com/hashgraph/hedera-sdk-go/v2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/term"
+
+	"github.com/christiaanpauw/surveyCTOprover/canon"
+	hedera "github.com/hiero-ledger/hiero-sdk-go/v2"
 )
 
+// chunkEnvelopeSchema identifies the envelope format used by -chunk mode so
+// the verifier knows how to reassemble fragments it receives out of band.
+const chunkEnvelopeSchema = "surveyctoprover.chunk.v1"
+
+// hcsMessageSizeLimit is Hedera's per-message payload ceiling (mainnet topic
+// messages top out around 1024 bytes).
+const hcsMessageSizeLimit = 1024
+
+// chunkEnvelope is one ordered fragment of a larger message, submitted as its
+// own HCS message. The verifier reassembles these by recordHashHex.
+type chunkEnvelope struct {
+	Schema        string `json:"schema"`
+	RecordHashHex string `json:"recordHashHex"`
+	ChunkIndex    int    `json:"chunkIndex"`
+	ChunkTotal    int    `json:"chunkTotal"`
+	ContentB64    string `json:"contentB64"`
+}
+
+// defaultChunkSize is the raw (pre-base64) fragment size used when -chunk-size
+// isn't given. It's derived from hcsMessageSizeLimit minus the worst-case
+// marshaled size of a chunkEnvelope with an empty contentB64, rounded down to
+// a multiple of 3 so the base64 expansion lands exactly on budget instead of
+// being guessed at and silently blowing past the mainnet message cap.
+var defaultChunkSize = computeDefaultChunkSize()
+
+// chunkEnvelopeOverhead returns the marshaled size of a chunkEnvelope with
+// worst-case field widths and an empty contentB64 — i.e. every byte of a
+// fragment's envelope except the fragment payload itself.
+func chunkEnvelopeOverhead() int {
+	env := chunkEnvelope{
+		Schema:        chunkEnvelopeSchema,
+		RecordHashHex: strings.Repeat("0", 64),
+		ChunkIndex:    999999,
+		ChunkTotal:    999999,
+		ContentB64:    "",
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+func computeDefaultChunkSize() int {
+	budget := hcsMessageSizeLimit - chunkEnvelopeOverhead()
+	raw := (budget / 4) * 3
+	if raw < 1 {
+		raw = 1
+	}
+	return raw
+}
+
+// keystoreIterations is the default PBKDF2-HMAC-SHA256 iteration count used
+// when a keystore is created; chosen to keep unlock under a second while
+// still being expensive to brute force offline.
+const keystoreIterations = 262144
+
+const keystoreSaltLen = 32
+
+// keystoreFile is the on-disk encrypted operator key format: the private key
+// is encrypted with AES-CTR under a key derived from a passphrase via
+// PBKDF2-HMAC-SHA256, with a separate HMAC-SHA256 over the ciphertext for
+// integrity. All binary fields are hex-encoded.
+type keystoreFile struct {
+	Salt       string `json:"salt"`
+	Iterations int    `json:"iterations"`
+	IV         string `json:"iv"`
+	Ciphertext string `json:"ciphertext"`
+	MAC        string `json:"mac"`
+}
+
 var dotEnv map[string]string
 var dotEnvLoaded bool
 
@@ -53,12 +137,457 @@ func mustEnv(key string) string {
 	return v
 }
 
+// splitChunks slices msg into ordered fragments no larger than size bytes.
+// A single zero-length fragment is returned for an empty message so chunked
+// mode always submits at least one envelope.
+func splitChunks(msg []byte, size int) [][]byte {
+	if len(msg) == 0 {
+		return [][]byte{msg}
+	}
+	var chunks [][]byte
+	for i := 0; i < len(msg); i += size {
+		end := i + size
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunks = append(chunks, msg[i:end])
+	}
+	return chunks
+}
+
+// deriveKeystoreKeys runs PBKDF2-HMAC-SHA256 over passphrase and splits the
+// 64-byte output into a 32-byte AES key and a 32-byte HMAC key.
+func deriveKeystoreKeys(passphrase string, salt []byte, iterations int) (encKey, macKey []byte) {
+	dk := pbkdf2.Key([]byte(passphrase), salt, iterations, 64, sha256.New)
+	return dk[:32], dk[32:64]
+}
+
+// encryptKeystore encrypts plaintext (the operator private key string) under
+// passphrase, returning the on-disk keystore representation.
+func encryptKeystore(plaintext []byte, passphrase string) (*keystoreFile, error) {
+	salt := make([]byte, keystoreSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("generate iv: %w", err)
+	}
+
+	encKey, macKey := deriveKeystoreKeys(passphrase, salt, keystoreIterations)
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, fmt.Errorf("init cipher: %w", err)
+	}
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, plaintext)
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(ciphertext)
+	macSum := mac.Sum(nil)
+
+	return &keystoreFile{
+		Salt:       hex.EncodeToString(salt),
+		Iterations: keystoreIterations,
+		IV:         hex.EncodeToString(iv),
+		Ciphertext: hex.EncodeToString(ciphertext),
+		MAC:        hex.EncodeToString(macSum),
+	}, nil
+}
+
+// decryptKeystore reverses encryptKeystore, returning the plaintext operator
+// key, and fails closed if the integrity MAC does not match.
+func decryptKeystore(ks *keystoreFile, passphrase string) ([]byte, error) {
+	salt, err := hex.DecodeString(ks.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	iv, err := hex.DecodeString(ks.IV)
+	if err != nil {
+		return nil, fmt.Errorf("invalid iv: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(ks.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	wantMAC, err := hex.DecodeString(ks.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac: %w", err)
+	}
+
+	encKey, macKey := deriveKeystoreKeys(passphrase, salt, ks.Iterations)
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(ciphertext)
+	if !hmac.Equal(mac.Sum(nil), wantMAC) {
+		return nil, fmt.Errorf("keystore MAC mismatch (wrong passphrase or corrupted file)")
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, fmt.Errorf("init cipher: %w", err)
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, iv).XORKeyStream(plaintext, ciphertext)
+	return plaintext, nil
+}
+
+// keystorePassphrase reads HEDERA_KEY_PASSPHRASE, falling back to an
+// interactive, non-echoing prompt when running on a TTY.
+func keystorePassphrase() string {
+	if v := os.Getenv("HEDERA_KEY_PASSPHRASE"); v != "" {
+		return v
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		log.Fatal("HEDERA_KEY_PASSPHRASE is not set and stdin is not a TTY to prompt on")
+	}
+	fmt.Fprint(os.Stderr, "Keystore passphrase: ")
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		log.Fatalf("failed to read passphrase: %v", err)
+	}
+	return string(b)
+}
+
+// loadOperatorKeyFromKeystore reads and decrypts path, returning the
+// plaintext operator private key string.
+func loadOperatorKeyFromKeystore(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("failed to read keystore %s: %v", path, err)
+	}
+	var ks keystoreFile
+	if err := json.Unmarshal(b, &ks); err != nil {
+		log.Fatalf("failed to parse keystore %s: %v", path, err)
+	}
+	plaintext, err := decryptKeystore(&ks, keystorePassphrase())
+	if err != nil {
+		log.Fatalf("failed to unlock keystore %s: %v", path, err)
+	}
+	return string(plaintext)
+}
+
+// runKeystoreCreate implements the `keystore-create` subcommand: it encrypts
+// a plaintext operator key under a passphrase and writes it to -out with
+// 0600 permissions.
+func runKeystoreCreate(args []string) {
+	fs := flag.NewFlagSet("keystore-create", flag.ExitOnError)
+	key := fs.String("key", "", "Plaintext operator private key to encrypt (required)")
+	out := fs.String("out", "", "Path to write the encrypted keystore JSON to (required)")
+	fs.Parse(args)
+
+	if *key == "" || *out == "" {
+		log.Fatal("usage: post_hcs keystore-create -key <privateKey> -out <path>")
+	}
+
+	ks, err := encryptKeystore([]byte(*key), keystorePassphrase())
+	if err != nil {
+		log.Fatalf("failed to encrypt keystore: %v", err)
+	}
+
+	b, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to marshal keystore: %v", err)
+	}
+	if err := ioutil.WriteFile(*out, b, 0o600); err != nil {
+		log.Fatalf("failed to write keystore %s: %v", *out, err)
+	}
+	fmt.Fprintf(os.Stderr, "encrypted keystore written to %s\n", *out)
+}
+
+// batchEnvelopeSchema identifies the envelope the batch subcommand submits:
+// only the Merkle root of a batch's record hashes, not the records
+// themselves.
+const batchEnvelopeSchema = "surveyctoprover.batch.v1"
+
+type batchEnvelope struct {
+	Schema       string `json:"schema"`
+	BatchRootHex string `json:"batchRootHex"`
+	RecordCount  int    `json:"recordCount"`
+}
+
+// merkleProofStep is one sibling on a record hash's path to the batch root.
+// Position names where the sibling sits relative to the node being proved.
+type merkleProofStep struct {
+	SiblingHex string `json:"siblingHex"`
+	Position   string `json:"position"` // "left" or "right"
+}
+
+// batchProofEntry is what a single record needs to prove inclusion in a
+// batch: its path to the root plus where the root itself was anchored.
+type batchProofEntry struct {
+	Path               []merkleProofStep `json:"path"`
+	SequenceNumber     uint64            `json:"sequenceNumber"`
+	ConsensusTimestamp string            `json:"consensusTimestamp"`
+}
+
+// batchProofsFile is the proofs.json sidecar the batch subcommand writes.
+type batchProofsFile struct {
+	BatchRootHex string                     `json:"batchRootHex"`
+	TopicID      string                     `json:"topicId"`
+	Records      map[string]batchProofEntry `json:"records"`
+}
+
+// recordHashForBundle computes a record hash from a {data.json, attachments/}
+// bundle exactly as the verifier does: canonicalize data.json per RFC 8785,
+// hash it, Merkle-root the attachment hashes, and hash the two together.
+func recordHashForBundle(bundleDir string, schema *canon.JSONSchema) ([]byte, error) {
+	data, err := loadJSONFile(filepath.Join(bundleDir, "data.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read data.json: %w", err)
+	}
+
+	var typed interface{} = data
+	if schema != nil {
+		typed, err = canon.CoerceToSchema(data, schema)
+		if err != nil {
+			return nil, fmt.Errorf("schema coercion: %w", err)
+		}
+	}
+
+	canonBytes, err := canon.CanonicalMarshal(typed)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize data.json: %w", err)
+	}
+	dataHash := sha256Bytes(canonBytes)
+
+	var attHashes [][]byte
+	attDir := filepath.Join(bundleDir, "attachments")
+	entries, err := ioutil.ReadDir(attDir)
+	if err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			b, err := ioutil.ReadFile(filepath.Join(attDir, e.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("read attachment %s: %w", e.Name(), err)
+			}
+			attHashes = append(attHashes, sha256Bytes(b))
+		}
+	}
+	attRoot := attachmentMerkleRoot(attHashes)
+
+	return sha256Bytes(append(dataHash, attRoot...)), nil
+}
+
+// merkleLeafPrefix and merkleNodePrefix domain-separate leaf hashes from
+// internal node hashes (RFC 6962-style), so an internal node's hash can
+// never be replayed as the leaf hash of a record that was never in the
+// batch — without this, a leaf A with no sibling produces a subtree root
+// of H(A||A), which is otherwise indistinguishable from a legitimate leaf
+// hash and can be passed off as one in a forged one-step inclusion proof.
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+// merkleLevels builds every level of a Merkle tree over leaves, preserving
+// their original order (unlike attachmentMerkleRoot's sorted root) so each
+// leaf's index can later be turned into an inclusion proof. An odd node at
+// a level is paired with itself. Leaf and internal hashes are
+// domain-separated per merkleLeafPrefix/merkleNodePrefix.
+func merkleLevels(leaves [][]byte) [][][]byte {
+	if len(leaves) == 0 {
+		return [][][]byte{{make([]byte, 32)}}
+	}
+	leafLevel := make([][]byte, len(leaves))
+	for i, l := range leaves {
+		h := sha256.New()
+		h.Write([]byte{merkleLeafPrefix})
+		h.Write(l)
+		leafLevel[i] = h.Sum(nil)
+	}
+	levels := [][][]byte{leafLevel}
+	cur := leafLevel
+	for len(cur) > 1 {
+		next := make([][]byte, 0, (len(cur)+1)/2)
+		for i := 0; i < len(cur); i += 2 {
+			left := cur[i]
+			right := left
+			if i+1 < len(cur) {
+				right = cur[i+1]
+			}
+			h := sha256.New()
+			h.Write([]byte{merkleNodePrefix})
+			h.Write(left)
+			h.Write(right)
+			next = append(next, h.Sum(nil))
+		}
+		levels = append(levels, next)
+		cur = next
+	}
+	return levels
+}
+
+// merkleInclusionProof returns the sibling path from leaf index leafIdx up
+// to levels' root.
+func merkleInclusionProof(levels [][][]byte, leafIdx int) []merkleProofStep {
+	proof := make([]merkleProofStep, 0, len(levels)-1)
+	idx := leafIdx
+	for level := 0; level < len(levels)-1; level++ {
+		nodes := levels[level]
+		sibIdx, pos := idx+1, "right"
+		if idx%2 == 1 {
+			sibIdx, pos = idx-1, "left"
+		}
+		if sibIdx >= len(nodes) {
+			sibIdx = idx
+		}
+		proof = append(proof, merkleProofStep{
+			SiblingHex: fmt.Sprintf("%x", nodes[sibIdx]),
+			Position:   pos,
+		})
+		idx /= 2
+	}
+	return proof
+}
+
+// runBatch implements the `batch` subcommand: it hashes every bundle under
+// -dir the way the verifier would, submits only the Merkle root of those
+// record hashes to HCS, and writes a proofs.json sidecar so any individual
+// record can later prove its inclusion without re-publishing the batch.
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory of bundle subdirectories, each containing data.json and an attachments/ folder (required)")
+	out := fs.String("out", "proofs.json", "Path to write the inclusion-proof sidecar to")
+	keystorePath := fs.String("keystore", "", "Path to an encrypted keystore file (see keystore-create); overrides OPERATOR_KEY")
+	schemaPath := fs.String("schema", "", "Optional JSON Schema file; when set, each bundle's data.json is coerced the same way `verify -schema` coerces it, so recordHash matches exactly")
+	fs.Parse(args)
+
+	if *dir == "" {
+		log.Fatal("usage: post_hcs batch -dir <bundles_dir> [-out proofs.json] [-schema path] [-keystore path]")
+	}
+
+	var schema *canon.JSONSchema
+	if *schemaPath != "" {
+		s, err := canon.LoadSchema(*schemaPath)
+		if err != nil {
+			log.Fatalf("failed to read -schema %s: %v", *schemaPath, err)
+		}
+		schema = s
+	}
+
+	entries, err := ioutil.ReadDir(*dir)
+	if err != nil {
+		log.Fatalf("failed to read -dir %s: %v", *dir, err)
+	}
+
+	var bundleNames []string
+	var leaves [][]byte
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		h, err := recordHashForBundle(filepath.Join(*dir, e.Name()), schema)
+		if err != nil {
+			log.Fatalf("bundle %s: %v", e.Name(), err)
+		}
+		bundleNames = append(bundleNames, e.Name())
+		leaves = append(leaves, h)
+	}
+	if len(leaves) == 0 {
+		log.Fatalf("no bundle subdirectories found under %s", *dir)
+	}
+
+	levels := merkleLevels(leaves)
+	batchRoot := levels[len(levels)-1][0]
+	batchRootHex := fmt.Sprintf("%x", batchRoot)
+
+	network := mustEnv("HEDERA_NETWORK")
+	operatorID := mustEnv("OPERATOR_ID")
+	var operatorKey string
+	if *keystorePath != "" {
+		operatorKey = loadOperatorKeyFromKeystore(*keystorePath)
+	} else {
+		operatorKey = mustEnv("OPERATOR_KEY")
+	}
+	topicIDStr := mustEnv("TOPIC_ID")
+
+	client := hedera.ClientForName(network)
+	accID, err := hedera.AccountIDFromString(operatorID)
+	if err != nil {
+		log.Fatalf("invalid OPERATOR_ID: %v", err)
+	}
+	privKey, err := hedera.PrivateKeyFromString(operatorKey)
+	if err != nil {
+		log.Fatalf("invalid OPERATOR_KEY: %v", err)
+	}
+	client.SetOperator(accID, privKey)
+
+	topicID, err := hedera.TopicIDFromString(topicIDStr)
+	if err != nil {
+		log.Fatalf("invalid TOPIC_ID: %v", err)
+	}
+
+	envBytes, err := json.Marshal(batchEnvelope{
+		Schema:       batchEnvelopeSchema,
+		BatchRootHex: batchRootHex,
+		RecordCount:  len(leaves),
+	})
+	if err != nil {
+		log.Fatalf("failed to marshal batch envelope: %v", err)
+	}
+
+	tx, err := hedera.NewTopicMessageSubmitTransaction().
+		SetTopicID(topicID).
+		SetMessage(envBytes).
+		Execute(client)
+	if err != nil {
+		log.Fatalf("HCS submit failed: %v", err)
+	}
+	receipt, err := tx.GetReceipt(client)
+	if err != nil {
+		log.Fatalf("HCS receipt failed: %v", err)
+	}
+	consensusTimestamp := receipt.Timestamp.Format("2006-01-02T15:04:05.999999999Z07:00")
+
+	proofs := batchProofsFile{
+		BatchRootHex: batchRootHex,
+		TopicID:      topicID.String(),
+		Records:      make(map[string]batchProofEntry, len(leaves)),
+	}
+	for i, leaf := range leaves {
+		proofs.Records[fmt.Sprintf("%x", leaf)] = batchProofEntry{
+			Path:               merkleInclusionProof(levels, i),
+			SequenceNumber:     receipt.TopicSequenceNumber,
+			ConsensusTimestamp: consensusTimestamp,
+		}
+	}
+
+	b, err := json.MarshalIndent(proofs, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to marshal proofs sidecar: %v", err)
+	}
+	if err := ioutil.WriteFile(*out, b, 0o644); err != nil {
+		log.Fatalf("failed to write proofs sidecar %s: %v", *out, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d record(s) anchored under batch root %s (proofs written to %s)\n", len(leaves), batchRootHex, *out)
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "keystore-create" {
+		runKeystoreCreate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		runBatch(os.Args[2:])
+		return
+	}
+
+	var attPaths multiFlag
 	file := flag.String("file", "", "Path to a file whose contents will be the HCS message (JSON recommended)")
+	chunked := flag.Bool("chunk", false, "Split the message into ordered envelopes and submit them sequentially")
+	chunkSize := flag.Int("chunk-size", defaultChunkSize, "Maximum raw bytes per fragment when -chunk is set")
+	keystorePath := flag.String("keystore", "", "Path to an encrypted keystore file (see keystore-create); overrides OPERATOR_KEY")
+	flag.Var(&attPaths, "att", "Attachment file path (repeatable); only consulted with -chunk, so the tagged recordHashHex matches what verify -data -att computes")
 	flag.Parse()
 
 	if *file == "" {
-		log.Fatal("usage: post_hcs -file <path>")
+		log.Fatal("usage: post_hcs -file <path> [-chunk] [-chunk-size N] [-att path ...] [-keystore path]")
 	}
 
 	msg, err := ioutil.ReadFile(*file)
@@ -68,7 +597,12 @@ func main() {
 
 	network := mustEnv("HEDERA_NETWORK") // "testnet" or "mainnet"
 	operatorID := mustEnv("OPERATOR_ID")
-	operatorKey := mustEnv("OPERATOR_KEY")
+	var operatorKey string
+	if *keystorePath != "" {
+		operatorKey = loadOperatorKeyFromKeystore(*keystorePath)
+	} else {
+		operatorKey = mustEnv("OPERATOR_KEY")
+	}
 	topicIDStr := mustEnv("TOPIC_ID")
 
 	client := hedera.ClientForName(network)
@@ -87,6 +621,15 @@ func main() {
 		log.Fatalf("invalid TOPIC_ID: %v", err)
 	}
 
+	if *chunked {
+		recordHashHex, err := recordHashForSubmission(*file, attPaths)
+		if err != nil {
+			log.Fatalf("failed to compute recordHashHex for -file: %v", err)
+		}
+		submitChunked(client, topicID, msg, *chunkSize, recordHashHex)
+		return
+	}
+
 	tx, err := hedera.NewTopicMessageSubmitTransaction().
 		SetTopicID(topicID).
 		SetMessage(msg).
@@ -112,3 +655,146 @@ func main() {
 	_ = enc.Encode(out)
 	fmt.Fprintln(os.Stderr, "message submitted to HCS")
 }
+
+// loadJSONFile reads path as a JSON object, preserving numbers as
+// json.Number so canon.CanonicalMarshal can render them per RFC 8785 rather
+// than through their original (possibly non-canonical) literal form.
+func loadJSONFile(path string) (map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func sha256Bytes(b []byte) []byte {
+	h := sha256.Sum256(b)
+	return h[:]
+}
+
+// attachmentMerkleRoot hashes a bundle's attachments into a single root the
+// same way the verifier does: sort the leaf hashes for determinism and
+// discard intermediate levels, since attachments don't need individual
+// inclusion proofs the way batch record hashes do.
+func attachmentMerkleRoot(hashes [][]byte) []byte {
+	if len(hashes) == 0 {
+		return make([]byte, 32)
+	}
+	cp := make([][]byte, len(hashes))
+	copy(cp, hashes)
+	sort.Slice(cp, func(i, j int) bool {
+		return strings.Compare(string(cp[i]), string(cp[j])) < 0
+	})
+	level := cp
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			left := level[i]
+			right := left
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, sha256Bytes(append(left, right...)))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// recordHashForSubmission computes the same recordHashHex verify.go derives
+// for -data/-att (canonicalize the JSON per RFC 8785, hash it, Merkle-root the
+// attachment hashes, hash the two together), so a chunked submission's tag
+// can be matched by a genuine verify run instead of by a hash of the raw
+// file bytes that verify.go never computes.
+func recordHashForSubmission(dataPath string, attPaths []string) (string, error) {
+	data, err := loadJSONFile(dataPath)
+	if err != nil {
+		return "", fmt.Errorf("read -file as JSON: %w", err)
+	}
+	canonBytes, err := canon.CanonicalMarshal(data)
+	if err != nil {
+		return "", fmt.Errorf("canonicalize -file: %w", err)
+	}
+	dataHash := sha256Bytes(canonBytes)
+
+	var attHashes [][]byte
+	for _, p := range attPaths {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return "", fmt.Errorf("read attachment %s: %w", p, err)
+		}
+		attHashes = append(attHashes, sha256Bytes(b))
+	}
+	attRoot := attachmentMerkleRoot(attHashes)
+
+	recordHash := sha256Bytes(append(dataHash, attRoot...))
+	return fmt.Sprintf("%x", recordHash), nil
+}
+
+// submitChunked wraps msg into ordered envelopes and submits each one as its
+// own HCS message, sequentially, so the verifier can reassemble them by
+// recordHashHex once every fragment has landed.
+func submitChunked(client *hedera.Client, topicID hedera.TopicID, msg []byte, chunkSize int, recordHashHex string) {
+	chunks := splitChunks(msg, chunkSize)
+
+	sequenceNumbers := make([]uint64, 0, len(chunks))
+	for i, c := range chunks {
+		env := chunkEnvelope{
+			Schema:        chunkEnvelopeSchema,
+			RecordHashHex: recordHashHex,
+			ChunkIndex:    i,
+			ChunkTotal:    len(chunks),
+			ContentB64:    base64.StdEncoding.EncodeToString(c),
+		}
+		envBytes, err := json.Marshal(env)
+		if err != nil {
+			log.Fatalf("failed to marshal chunk envelope %d/%d: %v", i, len(chunks), err)
+		}
+
+		tx, err := hedera.NewTopicMessageSubmitTransaction().
+			SetTopicID(topicID).
+			SetMessage(envBytes).
+			Execute(client)
+		if err != nil {
+			log.Fatalf("HCS submit failed for chunk %d/%d: %v", i, len(chunks), err)
+		}
+
+		receipt, err := tx.GetReceipt(client)
+		if err != nil {
+			log.Fatalf("HCS receipt failed for chunk %d/%d: %v", i, len(chunks), err)
+		}
+
+		sequenceNumbers = append(sequenceNumbers, receipt.TopicSequenceNumber)
+	}
+
+	out := map[string]interface{}{
+		"ok":               true,
+		"topicId":          topicID.String(),
+		"transactionGroup": "grp-" + recordHashHex[:16],
+		"recordHashHex":    recordHashHex,
+		"chunkTotal":       len(chunks),
+		"sequenceNumbers":  sequenceNumbers,
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(out)
+	fmt.Fprintf(os.Stderr, "%d chunk(s) submitted to HCS under record %s\n", len(chunks), recordHashHex)
+}
+
+// multiFlag collects repeatable -att flags.
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
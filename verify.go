@@ -4,70 +4,498 @@ package main
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/christiaanpauw/surveyCTOprover/canon"
 	hedera "github.com/hiero-ledger/hiero-sdk-go/v2"
 )
 
-// canonicalMarshal recursively sorts map keys and renders minimal JSON.
-// It accepts typical decoded JSON types: map[string]interface{}, []interface{}, string, float64, bool, nil.
-// If you have schema knowledge (ints vs floats), convert prior to calling for stricter numeric control.
-func canonicalMarshal(v interface{}) ([]byte, error) {
-	switch x := v.(type) {
-	case map[string]interface{}:
-		keys := make([]string, 0, len(x))
-		for k := range x {
-			keys = append(keys, k)
+// mirrorRestRoots are the default Mirror Node REST API bases per network,
+// used for -mode rest when no explicit -mirror URL is given.
+var mirrorRestRoots = map[string]string{
+	"mainnet":    "https://mainnet-public.mirrornode.hedera.com",
+	"testnet":    "https://testnet.mirrornode.hedera.com",
+	"previewnet": "https://previewnet.mirrornode.hedera.com",
+}
+
+// mirrorMessage is one entry of a Mirror Node REST topic-messages page.
+type mirrorMessage struct {
+	ConsensusTimestamp string `json:"consensus_timestamp"`
+	Message            string `json:"message"`
+	SequenceNumber     uint64 `json:"sequence_number"`
+}
+
+// mirrorMessagesPage is the REST response shape for
+// GET /api/v1/topics/{topicId}/messages.
+type mirrorMessagesPage struct {
+	Messages []mirrorMessage `json:"messages"`
+	Links    struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+// parseMirrorTimestamp parses a Mirror Node "seconds.nanoseconds" timestamp.
+func parseMirrorTimestamp(s string) time.Time {
+	parts := strings.SplitN(s, ".", 2)
+	sec, _ := strconv.ParseInt(parts[0], 10, 64)
+	var nsec int64
+	if len(parts) == 2 {
+		nsStr := parts[1]
+		for len(nsStr) < 9 {
+			nsStr += "0"
+		}
+		nsec, _ = strconv.ParseInt(nsStr[:9], 10, 64)
+	}
+	return time.Unix(sec, nsec).UTC()
+}
+
+// restVerify walks the Mirror Node REST API for topicIDStr's messages,
+// following the links.next pagination cursor, and feeds each decoded message
+// through onMessage using the same comparison logic as the gRPC path. It is
+// a light alternative to subscribeWithFailover: no long-lived connection, and
+// trivially resumable since the cursor is just a consensus_timestamp.
+//
+// ctx is expected to carry its own deadline semantics independent of the
+// gRPC path's -timeout (see verificationContext): restVerify returns
+// ctx.Err() as soon as it sees the context is done, rather than swallowing
+// it, so callers can tell context.DeadlineExceeded (pagination cut short,
+// result incomplete) apart from context.Canceled (onMessage found a match
+// and asked to stop).
+func restVerify(ctx context.Context, network string, mirrors []string, topicIDStr string, startRFC3339 string, onMessage func(hedera.TopicMessage)) error {
+	root := ""
+	if len(mirrors) > 0 {
+		root = strings.TrimRight(mirrors[0], "/")
+	} else if r, ok := mirrorRestRoots[network]; ok {
+		root = r
+	} else {
+		return fmt.Errorf("no default mirror REST endpoint for network %q; pass -mirror", network)
+	}
+
+	q := url.Values{}
+	q.Set("limit", "100")
+	q.Set("order", "asc")
+	if startRFC3339 != "" {
+		ts, err := time.Parse(time.RFC3339, startRFC3339)
+		if err != nil {
+			return fmt.Errorf("invalid -start time (RFC3339): %w", err)
+		}
+		q.Set("timestamp", fmt.Sprintf("gte:%d.%09d", ts.Unix(), ts.Nanosecond()))
+	}
+
+	next := fmt.Sprintf("/api/v1/topics/%s/messages?%s", topicIDStr, q.Encode())
+	httpClient := &http.Client{}
+
+	for next != "" {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, root+next, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("mirror REST request to %s failed: %w", root, err)
+		}
+
+		var page mirrorMessagesPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("decode mirror REST response: %w", err)
 		}
-		sort.Strings(keys)
-		var b strings.Builder
-		b.WriteByte('{')
-		for i, k := range keys {
-			kb, _ := json.Marshal(k)
-			b.Write(kb)
-			b.WriteByte(':')
-			vb, err := canonicalMarshal(x[k])
+
+		for _, m := range page.Messages {
+			contents, err := base64.StdEncoding.DecodeString(m.Message)
 			if err != nil {
-				return nil, err
+				continue
 			}
-			b.Write(vb)
-			if i < len(keys)-1 {
-				b.WriteByte(',')
+			onMessage(hedera.TopicMessage{
+				ConsensusTimestamp: parseMirrorTimestamp(m.ConsensusTimestamp),
+				SequenceNumber:     m.SequenceNumber,
+				Contents:           contents,
+			})
+			if err := ctx.Err(); err != nil {
+				return err
 			}
 		}
-		b.WriteByte('}')
-		return []byte(b.String()), nil
-
-	case []interface{}:
-		var b strings.Builder
-		b.WriteByte('[')
-		for i := range x {
-			vb, err := canonicalMarshal(x[i])
+
+		next = page.Links.Next
+	}
+	return nil
+}
+
+// verificationContext builds the context a verification run (direct search
+// or -proof) executes under. -mode grpc keeps the existing -timeout-bounded
+// deadline, since that's what caps how long the live subscription is held
+// open. -mode rest's whole point is avoiding a held-open connection, so
+// unlike gRPC it does not default to being bounded by -timeout at all: with
+// restTimeoutSec <= 0 it returns a context that only ends via cancel() (a
+// match found), and pagination runs to exhaustion. Pass restTimeoutSec > 0
+// to cap it anyway (e.g. for scripted/CI use); restVerify then returns
+// context.DeadlineExceeded so callers can report a cutoff as incomplete
+// rather than indistinguishable from a genuine non-match.
+func verificationContext(mode string, timeoutSec, restTimeoutSec int) (context.Context, context.CancelFunc) {
+	if mode == "rest" {
+		if restTimeoutSec > 0 {
+			return context.WithTimeout(context.Background(), time.Duration(restTimeoutSec)*time.Second)
+		}
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+}
+
+// chunkEnvelopeSchema must match the envelope format post_hcs.go's -chunk
+// mode submits, so fragments can be reassembled by recordHashHex.
+const chunkEnvelopeSchema = "surveyctoprover.chunk.v1"
+
+// batchEnvelopeSchema must match the envelope post_hcs.go's `batch`
+// subcommand submits: only a batch's Merkle root, not its records.
+const batchEnvelopeSchema = "surveyctoprover.batch.v1"
+
+type batchEnvelope struct {
+	Schema       string `json:"schema"`
+	BatchRootHex string `json:"batchRootHex"`
+	RecordCount  int    `json:"recordCount"`
+}
+
+// merkleProofStep is one sibling on a record hash's path to a batch root, as
+// written by post_hcs.go's `batch` subcommand.
+type merkleProofStep struct {
+	SiblingHex string `json:"siblingHex"`
+	Position   string `json:"position"` // "left" or "right"
+}
+
+type batchProofEntry struct {
+	Path               []merkleProofStep `json:"path"`
+	SequenceNumber     uint64            `json:"sequenceNumber"`
+	ConsensusTimestamp string            `json:"consensusTimestamp"`
+}
+
+// batchProofsFile is the proofs.json sidecar produced by `batch`.
+type batchProofsFile struct {
+	BatchRootHex string                     `json:"batchRootHex"`
+	TopicID      string                     `json:"topicId"`
+	Records      map[string]batchProofEntry `json:"records"`
+}
+
+// merkleLeafPrefix and merkleNodePrefix mirror post_hcs.go's merkleLevels:
+// leaf hashes and internal node hashes must be domain-separated the same
+// way on both the construction and verification side, or a forged proof
+// that passes off an internal node's hash as a leaf's would recompute to a
+// genuine batch root.
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+// recomputeMerkleRoot walks leafHex up through path, hashing with each
+// sibling in the recorded left/right order, and returns the resulting root
+// as hex.
+func recomputeMerkleRoot(leafHex string, path []merkleProofStep) (string, error) {
+	leaf, err := hex.DecodeString(leafHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid leaf hash: %w", err)
+	}
+	lh := sha256.New()
+	lh.Write([]byte{merkleLeafPrefix})
+	lh.Write(leaf)
+	cur := lh.Sum(nil)
+	for _, step := range path {
+		sib, err := hex.DecodeString(step.SiblingHex)
+		if err != nil {
+			return "", fmt.Errorf("invalid sibling hash: %w", err)
+		}
+		h := sha256.New()
+		h.Write([]byte{merkleNodePrefix})
+		if step.Position == "right" {
+			h.Write(cur)
+			h.Write(sib)
+		} else {
+			h.Write(sib)
+			h.Write(cur)
+		}
+		cur = h.Sum(nil)
+	}
+	return fmt.Sprintf("%x", cur), nil
+}
+
+// verifyBatchProof checks that recordHashHex's entry in the proofPath
+// sidecar recomputes to the sidecar's batchRootHex, then confirms that same
+// root was actually anchored on-chain for topicIDStr.
+func verifyBatchProof(ctx context.Context, cancel context.CancelFunc, proofPath, recordHashHex, network, topicIDStr, mode, startRFC3339 string, mirrors []string) {
+	b, err := ioutil.ReadFile(proofPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read proof sidecar %s: %v\n", proofPath, err)
+		os.Exit(1)
+	}
+	var proofs batchProofsFile
+	if err := json.Unmarshal(b, &proofs); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse proof sidecar %s: %v\n", proofPath, err)
+		os.Exit(1)
+	}
+	entry, ok := proofs.Records[recordHashHex]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "record %s is not present in proof sidecar %s\n", recordHashHex, proofPath)
+		os.Exit(1)
+	}
+
+	recomputedRoot, err := recomputeMerkleRoot(recordHashHex, entry.Path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to recompute Merkle root: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("recomputed batchRootHex: %s\n", recomputedRoot)
+	if !strings.EqualFold(recomputedRoot, proofs.BatchRootHex) {
+		fmt.Println("❌ Recomputed root does not match the sidecar's batchRootHex.")
+		os.Exit(3)
+	}
+
+	topicID, err := hedera.TopicIDFromString(topicIDStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid topic ID: %v\n", err)
+		os.Exit(1)
+	}
+
+	found := false
+	onMessage := func(msg hedera.TopicMessage) {
+		var env batchEnvelope
+		if err := json.Unmarshal(msg.Contents, &env); err == nil && env.Schema == batchEnvelopeSchema && strings.EqualFold(env.BatchRootHex, recomputedRoot) {
+			fmt.Println("✅ Record verified: its Merkle path matches a batch root anchored on-chain")
+			fmt.Printf("sequenceNumber: %d\n", msg.SequenceNumber)
+			fmt.Printf("consensusTimestamp: %s\n", msg.ConsensusTimestamp.Format(time.RFC3339Nano))
+			found = true
+			cancel()
+		}
+	}
+
+	truncated := false
+	switch mode {
+	case "rest":
+		if err := restVerify(ctx, network, mirrors, topicIDStr, startRFC3339, onMessage); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				truncated = true
+			} else if !errors.Is(err, context.Canceled) {
+				fmt.Fprintf(os.Stderr, "REST verification failed: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	default:
+		q := hedera.NewTopicMessageQuery().SetTopicID(topicID)
+		if startRFC3339 != "" {
+			ts, err := time.Parse(time.RFC3339, startRFC3339)
 			if err != nil {
-				return nil, err
+				fmt.Fprintf(os.Stderr, "Invalid -start time (RFC3339): %v\n", err)
+				os.Exit(1)
+			}
+			q.SetStartTime(ts)
+		}
+		subscribeWithFailover(ctx, network, mirrors, q, onMessage)
+	}
+
+	if !found {
+		if truncated {
+			fmt.Println("⚠️  -rest-timeout elapsed before pagination reached the end of topic history; scan is incomplete, not a confirmed non-match.")
+			fmt.Println("❌ No on-chain batch root matched the recomputed proof within -rest-timeout.")
+		} else {
+			fmt.Println("❌ No on-chain batch root matched the recomputed proof within the time window.")
+		}
+		os.Exit(3)
+	}
+}
+
+// chunkEnvelope is one ordered fragment of a larger message.
+type chunkEnvelope struct {
+	Schema        string `json:"schema"`
+	RecordHashHex string `json:"recordHashHex"`
+	ChunkIndex    int    `json:"chunkIndex"`
+	ChunkTotal    int    `json:"chunkTotal"`
+	ContentB64    string `json:"contentB64"`
+}
+
+// chunkState is the on-disk shape of a partially assembled chunked message,
+// so a subscription that dies mid-stream can resume with -start instead of
+// re-downloading fragments it already has.
+type chunkState struct {
+	RecordHashHex string            `json:"recordHashHex"`
+	ChunkTotal    int               `json:"chunkTotal"`
+	Fragments     map[string]string `json:"fragments"` // chunkIndex (string) -> base64 content
+	LastTimestamp string            `json:"lastTimestamp"`
+}
+
+const chunkStateDir = ".surveyctoprover-state"
+
+func chunkStatePath(recordHashHex string) string {
+	return filepath.Join(chunkStateDir, recordHashHex+".json")
+}
+
+func loadChunkState(recordHashHex string) *chunkState {
+	b, err := ioutil.ReadFile(chunkStatePath(recordHashHex))
+	if err != nil {
+		return &chunkState{RecordHashHex: recordHashHex, Fragments: map[string]string{}}
+	}
+	var st chunkState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return &chunkState{RecordHashHex: recordHashHex, Fragments: map[string]string{}}
+	}
+	if st.Fragments == nil {
+		st.Fragments = map[string]string{}
+	}
+	return &st
+}
+
+func saveChunkState(st *chunkState) error {
+	if err := os.MkdirAll(chunkStateDir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(chunkStatePath(st.RecordHashHex), b, 0o644)
+}
+
+// assembleChunks concatenates st's fragments in chunkIndex order and returns
+// the bytes along with whether every fragment (0..ChunkTotal-1) is present.
+func assembleChunks(st *chunkState) ([]byte, bool) {
+	if st.ChunkTotal == 0 || len(st.Fragments) < st.ChunkTotal {
+		return nil, false
+	}
+	var out []byte
+	for i := 0; i < st.ChunkTotal; i++ {
+		part, ok := st.Fragments[fmt.Sprintf("%d", i)]
+		if !ok {
+			return nil, false
+		}
+		b, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return nil, false
+		}
+		out = append(out, b...)
+	}
+	return out, true
+}
+
+// stallTimeout is how long a mirror node may go without delivering a message
+// before subscribeWithFailover treats it as stalled and fails over.
+const stallTimeout = 20 * time.Second
+
+// maxBackoff caps the exponential backoff applied between reconnect attempts.
+const maxBackoff = 30 * time.Second
+
+// subscribeWithFailover subscribes to q, round-robining across mirrors (if
+// more than one is given) whenever the current connection errors or stalls,
+// backing off exponentially between attempts. It honors ctx's deadline as
+// the overall cutoff and returns once ctx is done. With no mirrors given it
+// falls back to the network's default mirror node.
+func subscribeWithFailover(ctx context.Context, network string, mirrors []string, q *hedera.TopicMessageQuery, onMessage func(hedera.TopicMessage)) {
+	backoff := time.Second
+
+	for mirrorIdx := 0; ctx.Err() == nil; mirrorIdx++ {
+		client := hedera.ClientForName(network)
+		var node string
+		if len(mirrors) > 0 {
+			node = mirrors[mirrorIdx%len(mirrors)]
+			client.SetMirrorNetwork([]string{node})
+			fmt.Fprintf(os.Stderr, "using mirror node %s (attempt %d)\n", node, mirrorIdx+1)
+		}
+
+		subCtx, subCancel := context.WithCancel(ctx)
+		activity := make(chan struct{}, 1)
+		subErr := make(chan error, 1)
+
+		_, err := q.Subscribe(subCtx, client, func(msg hedera.TopicMessage) {
+			select {
+			case activity <- struct{}{}:
+			default:
 			}
-			b.Write(vb)
-			if i < len(x)-1 {
-				b.WriteByte(',')
+			onMessage(msg)
+		}, func(err error) {
+			if err != nil && subCtx.Err() == nil {
+				select {
+				case subErr <- err:
+				default:
+				}
 			}
+		})
+		if err != nil {
+			subCancel()
+			client.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "subscribe failed on %q: %v; backing off %s\n", node, err, backoff)
+			sleepOrDone(ctx, backoff)
+			backoff = nextBackoff(backoff)
+			continue
 		}
-		b.WriteByte(']')
-		return []byte(b.String()), nil
 
-	case string, float64, bool, nil:
-		return json.Marshal(x)
+		timer := time.NewTimer(stallTimeout)
+	watch:
+		for {
+			select {
+			case <-ctx.Done():
+				subCancel()
+				timer.Stop()
+				client.Close()
+				return
+			case <-activity:
+				backoff = time.Second
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(stallTimeout)
+			case serr := <-subErr:
+				fmt.Fprintf(os.Stderr, "mirror %q errored: %v; failing over\n", node, serr)
+				subCancel()
+				timer.Stop()
+				break watch
+			case <-timer.C:
+				fmt.Fprintf(os.Stderr, "mirror %q stalled (no messages for %s); failing over\n", node, stallTimeout)
+				subCancel()
+				break watch
+			}
+		}
+		client.Close()
 
-	default:
-		// Fallback to default JSON encoding for other concrete types
-		return json.Marshal(x)
+		if ctx.Err() != nil {
+			return
+		}
+		sleepOrDone(ctx, backoff)
+		backoff = nextBackoff(backoff)
+	}
+}
+
+func nextBackoff(cur time.Duration) time.Duration {
+	next := cur * 2
+	if next > maxBackoff {
+		return maxBackoff
+	}
+	return next
+}
+
+func sleepOrDone(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
 	}
 }
 
@@ -129,21 +557,31 @@ func readFileBytes(path string) ([]byte, error) {
 
 func main() {
 	var (
-		dataPath   string
-		attPaths   multiFlag
-		network    string
-		topicIDStr string
+		dataPath     string
+		attPaths     multiFlag
+		mirrorAddrs  multiFlag
+		network      string
+		topicIDStr   string
 		startRFC3339 string
-		timeoutSec int
+		timeoutSec   int
+		restTimeout  int
+		mode         string
+		schemaPath   string
+		proofPath    string
 		// Optional: operator credentials are NOT required for read-only queries; omit unless needed
 	)
 
 	flag.StringVar(&dataPath, "data", "", "Path to JSON file containing the logical submission data (required)")
 	flag.Var(&attPaths, "att", "Attachment file path (repeatable). Example: -att photo.jpg -att audio.wav")
+	flag.Var(&mirrorAddrs, "mirror", "Mirror node endpoint (repeatable). For -mode grpc, host:port, e.g. hcs.mainnet.mirrornode.hedera.com:443 (round-robins/fails over across all given). For -mode rest, a REST API base URL.")
 	flag.StringVar(&network, "network", "testnet", "Hedera network: testnet or mainnet")
 	flag.StringVar(&topicIDStr, "topic-id", "", "Hedera Topic ID to search (e.g., 0.0.1234567). If empty, only prints the computed hash.")
 	flag.StringVar(&startRFC3339, "start", "", "Optional start time (RFC3339) for topic search, e.g., 2025-08-18T00:00:00Z")
-	flag.IntVar(&timeoutSec, "timeout", 30, "Seconds to wait for query subscription before giving up")
+	flag.IntVar(&timeoutSec, "timeout", 30, "Seconds to wait for the gRPC query subscription before giving up (ignored in -mode rest; see -rest-timeout)")
+	flag.IntVar(&restTimeout, "rest-timeout", 0, "Seconds to bound -mode rest's pagination; 0 (default) means scan to exhaustion instead of cutting off mid-history")
+	flag.StringVar(&mode, "mode", "grpc", "Verification backend: grpc (long-lived subscription) or rest (paginated Mirror Node REST API, no open connection)")
+	flag.StringVar(&schemaPath, "schema", "", "Optional JSON Schema file; when set, leaf values in -data are coerced to their declared type before canonicalizing")
+	flag.StringVar(&proofPath, "proof", "", "Path to a proofs.json sidecar (see post_hcs batch); when set, verifies -data/-att's record via its Merkle inclusion path instead of searching for it directly")
 	flag.Parse()
 
 	if dataPath == "" {
@@ -158,12 +596,27 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to read data JSON: %v\n", err)
 		os.Exit(1)
 	}
-	canon, err := canonicalMarshal(data)
+
+	var typed interface{} = data
+	if schemaPath != "" {
+		schema, err := canon.LoadSchema(schemaPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read schema %s: %v\n", schemaPath, err)
+			os.Exit(1)
+		}
+		typed, err = canon.CoerceToSchema(data, schema)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Schema coercion failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	canonBytes, err := canon.CanonicalMarshal(typed)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Canonicalization failed: %v\n", err)
 		os.Exit(1)
 	}
-	dataHash := sha256Bytes(canon)
+	dataHash := sha256Bytes(canonBytes)
 
 	// Hash attachments
 	var attHashes [][]byte
@@ -194,9 +647,8 @@ func main() {
 		return
 	}
 
-	// Prepare Hedera client for read-only query
-	client := hedera.ClientForName(network)
-	// No operator needed for mirror/topic query
+	// No operator needed for mirror/topic query. The client itself is
+	// (re)created per mirror node by subscribeWithFailover below.
 
 	topicID, err := hedera.TopicIDFromString(topicIDStr)
 	if err != nil {
@@ -204,29 +656,57 @@ func main() {
 		os.Exit(1)
 	}
 
-	q := hedera.NewTopicMessageQuery().
-		SetTopicID(topicID)
+	if proofPath != "" {
+		ctx, cancel := verificationContext(mode, timeoutSec, restTimeout)
+		defer cancel()
+		verifyBatchProof(ctx, cancel, proofPath, recordHashHex, network, topicIDStr, mode, startRFC3339, mirrorAddrs)
+		return
+	}
 
-	if startRFC3339 != "" {
-		ts, err := time.Parse(time.RFC3339, startRFC3339)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Invalid -start time (RFC3339): %v\n", err)
-			os.Exit(1)
-		}
-		q.SetStartTime(ts)
+	// Resume a partially-assembled chunked submission if one exists for this
+	// record, defaulting -start to the last consensus timestamp we saw so we
+	// don't have to replay fragments already on disk.
+	chunkSt := loadChunkState(recordHashHex)
+	if startRFC3339 == "" && chunkSt.LastTimestamp != "" {
+		startRFC3339 = chunkSt.LastTimestamp
+		fmt.Printf("Resuming chunk assembly for %s (%d/%d fragments already seen) from %s\n",
+			recordHashHex, len(chunkSt.Fragments), chunkSt.ChunkTotal, startRFC3339)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+	ctx, cancel := verificationContext(mode, timeoutSec, restTimeout)
 	defer cancel()
 
-	fmt.Printf("Searching topic %s on %s for recordHashHex...\n", topicIDStr, network)
+	fmt.Printf("Searching topic %s on %s for recordHashHex (mode=%s)...\n", topicIDStr, network, mode)
 
 	found := false
-	_, err = q.Subscribe(ctx, client, func(msg hedera.TopicMessage) {
-		// Messages could be raw bytes (hash only) or JSON with recordHashHex field.
-		// Try to parse JSON; if that fails, compare direct hex of bytes.
+	onMessage := func(msg hedera.TopicMessage) {
+		// Messages could be raw bytes (hash only), JSON with recordHashHex
+		// field, or an ordered chunk envelope to be reassembled.
 		var obj map[string]interface{}
 		if err := json.Unmarshal(msg.Contents, &obj); err == nil {
+			if s, ok := obj["schema"].(string); ok && s == chunkEnvelopeSchema {
+				var env chunkEnvelope
+				if err := json.Unmarshal(msg.Contents, &env); err == nil && strings.EqualFold(env.RecordHashHex, recordHashHex) {
+					chunkSt.ChunkTotal = env.ChunkTotal
+					chunkSt.Fragments[fmt.Sprintf("%d", env.ChunkIndex)] = env.ContentB64
+					chunkSt.LastTimestamp = msg.ConsensusTimestamp.Format(time.RFC3339Nano)
+					_ = saveChunkState(chunkSt)
+
+					// Every fragment buffered above already matched
+					// recordHashHex (the envelope's two-stage hash over the
+					// *original* data+attachments, not the reassembled raw
+					// bytes), so once assembleChunks confirms every index is
+					// present there's nothing further to rehash against.
+					if _, ok := assembleChunks(chunkSt); ok {
+						fmt.Println("✅ Match found (reassembled from chunks)")
+						fmt.Printf("chunkTotal: %d\n", chunkSt.ChunkTotal)
+						fmt.Printf("consensusTimestamp: %s\n", chunkSt.LastTimestamp)
+						found = true
+						cancel()
+					}
+					return
+				}
+			}
 			if v, ok := obj["recordHashHex"].(string); ok && strings.EqualFold(v, recordHashHex) {
 				fmt.Println("✅ Match found in JSON message")
 				fmt.Printf("sequenceNumber: %d\n", msg.SequenceNumber)
@@ -245,20 +725,39 @@ func main() {
 			cancel()
 			return
 		}
-	}, func(err error) {
-		// subscription error
-		if err != nil && ctx.Err() == nil {
-			fmt.Fprintf(os.Stderr, "Subscription error: %v\n", err)
+	}
+
+	truncated := false
+	switch mode {
+	case "rest":
+		if err := restVerify(ctx, network, mirrorAddrs, topicIDStr, startRFC3339, onMessage); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				truncated = true
+			} else if !errors.Is(err, context.Canceled) {
+				fmt.Fprintf(os.Stderr, "REST verification failed: %v\n", err)
+				os.Exit(1)
+			}
 		}
-	})
-	if err != nil && ctx.Err() == nil {
-		fmt.Fprintf(os.Stderr, "Subscribe failed: %v\n", err)
-		os.Exit(1)
+	default:
+		q := hedera.NewTopicMessageQuery().SetTopicID(topicID)
+		if startRFC3339 != "" {
+			ts, err := time.Parse(time.RFC3339, startRFC3339)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid -start time (RFC3339): %v\n", err)
+				os.Exit(1)
+			}
+			q.SetStartTime(ts)
+		}
+		subscribeWithFailover(ctx, network, mirrorAddrs, q, onMessage)
 	}
 
-	<-ctx.Done()
 	if !found {
-		fmt.Println("❌ No matching message found within the time window.")
+		if truncated {
+			fmt.Println("⚠️  -rest-timeout elapsed before pagination reached the end of topic history; scan is incomplete, not a confirmed non-match.")
+			fmt.Println("❌ No matching message found within -rest-timeout.")
+		} else {
+			fmt.Println("❌ No matching message found within the time window.")
+		}
 		os.Exit(3)
 	}
 }